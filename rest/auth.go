@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestOptions carries per-request credentials and metadata that a
+// FileRESTAPI implementation may want to forward to the upstream REST
+// backend -- a bearer token, extra headers, or a tenant id -- without
+// threading them through every FileRESTAPI method signature.
+type RequestOptions struct {
+	BearerToken string
+	Headers     http.Header
+	TenantID    string
+}
+
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns a copy of ctx carrying opts, retrievable with
+// RequestOptionsFromContext.
+func WithRequestOptions(ctx context.Context, opts RequestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, opts)
+}
+
+// RequestOptionsFromContext returns the RequestOptions stored in ctx by
+// WithRequestOptions, and whether any were found.
+func RequestOptionsFromContext(ctx context.Context) (RequestOptions, bool) {
+	opts, ok := ctx.Value(requestOptionsKey{}).(RequestOptions)
+	return opts, ok
+}
+
+// AuthMiddleware wraps handler so that every request carries a
+// RequestOptions value -- produced by extractor from the incoming
+// *http.Request -- on its context. A FileRESTAPI implementation such as
+// rest/http.HTTPRestAPI can then read it back with RequestOptionsFromContext
+// and forward the caller's Authorization header, cookie, or federated token
+// to the upstream API on a per-request basis.
+func AuthMiddleware(extractor func(*http.Request) RequestOptions, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithRequestOptions(r.Context(), extractor(r))
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,166 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// BasePathAPI prefixes every path with Base before delegating to API,
+// afero's BasePathFs analog. It exposes the subtree of API rooted at Base
+// as its root: with Base "/a", a request for "/x" reaches API as "/a/x".
+type BasePathAPI struct {
+	Base string
+	API  FileRESTAPI
+}
+
+func (b *BasePathAPI) prefix(name string) string {
+	return path.Join("/", b.Base, name)
+}
+
+func (b *BasePathAPI) GetContent(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.API.GetContent(ctx, b.prefix(name))
+}
+
+func (b *BasePathAPI) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	return b.API.Stat(ctx, b.prefix(name))
+}
+
+func (b *BasePathAPI) GetChildren(ctx context.Context, name string) ([]fs.FileInfo, error) {
+	return b.API.GetChildren(ctx, b.prefix(name))
+}
+
+func (b *BasePathAPI) MkDir(ctx context.Context, name string, perm os.FileMode) error {
+	return b.API.MkDir(ctx, b.prefix(name), perm)
+}
+
+func (b *BasePathAPI) Update(ctx context.Context, name string, rc io.Reader) error {
+	return b.API.Update(ctx, b.prefix(name), rc)
+}
+
+func (b *BasePathAPI) NewFile(ctx context.Context, name string, rc io.Reader) error {
+	return b.API.NewFile(ctx, b.prefix(name), rc)
+}
+
+func (b *BasePathAPI) RemoveAll(ctx context.Context, name string) error {
+	return b.API.RemoveAll(ctx, b.prefix(name))
+}
+
+func (b *BasePathAPI) Rename(ctx context.Context, oldname string, newname string) error {
+	return b.API.Rename(ctx, b.prefix(oldname), b.prefix(newname))
+}
+
+// guard to ensure that everything works
+var _ FileRESTAPI = &BasePathAPI{}
+
+// NewBasePathAPI returns a FileRESTAPI that prefixes every path with base
+// before delegating to api, like &BasePathAPI{Base: base, API: api}, but
+// also forwards whichever of RangeGetter, ConditionalAPI and
+// ChunkedUploader api implements, with paths translated the same way.
+// Adding those methods directly to BasePathAPI wouldn't work: every
+// *BasePathAPI would then satisfy all three interfaces regardless of what
+// api actually supports, so File's f.api.(RangeGetter)-style capability
+// checks (see range.go/conditional.go/upload.go) would succeed and then
+// fail at call time instead of falling back cleanly. NewBasePathAPI
+// instead returns one of a fixed set of unexported types, each embedding
+// only the mixins for the capabilities api actually has, so those type
+// assertions behave exactly as they would against api directly.
+func NewBasePathAPI(base string, api FileRESTAPI) FileRESTAPI {
+	core := BasePathAPI{Base: base, API: api}
+	rg, hasRange := api.(RangeGetter)
+	cond, hasCond := api.(ConditionalAPI)
+	cu, hasChunked := api.(ChunkedUploader)
+
+	mr := rangeMixin{rg: rg, prefix: core.prefix}
+	mc := conditionalMixin{cond: cond, prefix: core.prefix}
+	mu := chunkedMixin{cu: cu, prefix: core.prefix}
+
+	switch {
+	case hasRange && hasCond && hasChunked:
+		return &struct {
+			BasePathAPI
+			rangeMixin
+			conditionalMixin
+			chunkedMixin
+		}{core, mr, mc, mu}
+	case hasRange && hasCond:
+		return &struct {
+			BasePathAPI
+			rangeMixin
+			conditionalMixin
+		}{core, mr, mc}
+	case hasRange && hasChunked:
+		return &struct {
+			BasePathAPI
+			rangeMixin
+			chunkedMixin
+		}{core, mr, mu}
+	case hasCond && hasChunked:
+		return &struct {
+			BasePathAPI
+			conditionalMixin
+			chunkedMixin
+		}{core, mc, mu}
+	case hasRange:
+		return &struct {
+			BasePathAPI
+			rangeMixin
+		}{core, mr}
+	case hasCond:
+		return &struct {
+			BasePathAPI
+			conditionalMixin
+		}{core, mc}
+	case hasChunked:
+		return &struct {
+			BasePathAPI
+			chunkedMixin
+		}{core, mu}
+	default:
+		return &core
+	}
+}
+
+// rangeMixin path-translates RangeGetter onto an underlying backend.
+type rangeMixin struct {
+	rg     RangeGetter
+	prefix func(string) string
+}
+
+func (m rangeMixin) GetContentRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return m.rg.GetContentRange(ctx, m.prefix(name), off, length)
+}
+
+// conditionalMixin path-translates ConditionalAPI onto an underlying backend.
+type conditionalMixin struct {
+	cond   ConditionalAPI
+	prefix func(string) string
+}
+
+func (m conditionalMixin) StatWithETag(ctx context.Context, name string) (fs.FileInfo, string, error) {
+	return m.cond.StatWithETag(ctx, m.prefix(name))
+}
+
+func (m conditionalMixin) GetContentIfNoneMatch(ctx context.Context, name, etag string) (io.ReadCloser, string, bool, error) {
+	return m.cond.GetContentIfNoneMatch(ctx, m.prefix(name), etag)
+}
+
+func (m conditionalMixin) UpdateIfMatch(ctx context.Context, name string, rc io.Reader, etag string) error {
+	return m.cond.UpdateIfMatch(ctx, m.prefix(name), rc, etag)
+}
+
+// chunkedMixin path-translates ChunkedUploader onto an underlying backend.
+type chunkedMixin struct {
+	cu     ChunkedUploader
+	prefix func(string) string
+}
+
+func (m chunkedMixin) StartUpload(ctx context.Context, name string) (UploadSession, error) {
+	return m.cu.StartUpload(ctx, m.prefix(name))
+}
+
+func (m chunkedMixin) ChunkSizeHints() (min, max int64) {
+	return m.cu.ChunkSizeHints()
+}
@@ -0,0 +1,448 @@
+package rest
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage is the minimal local-filesystem abstraction CachingAPI needs to
+// persist cached content. It's modeled on the subset of afero.Fs's method
+// set CachingAPI actually calls, so an afero.Fs (afero.NewMemMapFs(),
+// afero.NewOsFs(), ...) can be used as the cache's backing store with a
+// three-line adapter; NewOSStorage is the equivalent for the real local
+// filesystem and is used when no Storage is supplied.
+type Storage interface {
+	MkdirAll(path string, perm os.FileMode) error
+	Create(name string) (io.WriteCloser, error)
+	Open(name string) (io.ReadCloser, error)
+	Remove(name string) error
+}
+
+// NewOSStorage returns a Storage backed by the real local filesystem, rooted
+// under dir.
+func NewOSStorage(dir string) Storage {
+	return osStorage{dir: dir}
+}
+
+type osStorage struct {
+	dir string
+}
+
+func (s osStorage) path(name string) string {
+	return filepath.Join(s.dir, strings.ReplaceAll(name, "/", "_"))
+}
+
+func (s osStorage) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(filepath.Join(s.dir, path), perm)
+}
+
+func (s osStorage) Create(name string) (io.WriteCloser, error) {
+	return os.Create(s.path(name))
+}
+
+func (s osStorage) Open(name string) (io.ReadCloser, error) {
+	return os.Open(s.path(name))
+}
+
+func (s osStorage) Remove(name string) error {
+	err := os.Remove(s.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// cacheEntry holds what CachingAPI knows about a single path: its cached
+// metadata/children (if any), the ETag it was last validated against, and
+// the size of any cached content on disk for LRU accounting. Every access
+// to a *cacheEntry happens under CachingAPI.mu; callers that need to read
+// it outside the lock must go through CachingAPI.entry, which hands back a
+// copy, not the live pointer.
+type cacheEntry struct {
+	info       fs.FileInfo
+	children   []fs.FileInfo
+	etag       string
+	cachedAt   time.Time
+	hasContent bool
+	size       int64
+}
+
+func (e cacheEntry) fresh(ttl time.Duration) bool {
+	return time.Since(e.cachedAt) < ttl
+}
+
+// CachingAPI wraps a FileRESTAPI and caches GetContent, Stat and
+// GetChildren results locally, modeled on afero's cacheOnReadFs: reads
+// within TTL are served from the cache without touching Upstream, reads
+// past TTL are revalidated (via ConditionalAPI if Upstream implements it,
+// falling back to a plain Stat otherwise) rather than blindly refetched,
+// and writes invalidate the entries and parent listings they affect. This
+// replaces ad-hoc re-downloading of unchanged content on every File open
+// with a single coherent cache.
+//
+// CachingAPI does not forward Upstream's optional RangeGetter or
+// ChunkedUploader capabilities: both bypass GetContent/Write entirely, which
+// would leave the cache unaware of the bytes moving through them and able to
+// serve stale content afterward. It uses Upstream's ConditionalAPI
+// internally (GetContent revalidates via it when Upstream supports it), but
+// doesn't itself implement StatWithETag/GetContentIfNoneMatch/UpdateIfMatch,
+// so a ConditionalAPI type assertion on *CachingAPI fails -- a caller
+// needing those directly should talk to Upstream.
+type CachingAPI struct {
+	Upstream FileRESTAPI
+	TTL      time.Duration
+	MaxBytes int64 // 0 means unbounded
+	Storage  Storage
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	lru     *list.List // front = most recently used
+	lruElem map[string]*list.Element
+	curSize int64
+}
+
+// NewCachingAPI wraps upstream with a read-through cache of the given TTL,
+// storing content under dir on the local filesystem. maxBytes bounds the
+// total size of cached content, evicting least-recently-used entries once
+// exceeded; 0 means unbounded.
+func NewCachingAPI(upstream FileRESTAPI, dir string, ttl time.Duration, maxBytes int64) *CachingAPI {
+	return &CachingAPI{
+		Upstream: upstream,
+		TTL:      ttl,
+		MaxBytes: maxBytes,
+		Storage:  NewOSStorage(dir),
+		entries:  make(map[string]*cacheEntry),
+		lru:      list.New(),
+		lruElem:  make(map[string]*list.Element),
+	}
+}
+
+// entry returns a snapshot of the cache entry for name, safe to read
+// without holding c.mu. It never returns the live *cacheEntry: every field
+// on that pointer is mutated under the lock elsewhere, so handing it out
+// would race with those writers.
+func (c *CachingAPI) entry(name string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[name]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	return *e, true
+}
+
+// touchCachedAt bumps the cachedAt of name's entry to now, under the lock.
+func (c *CachingAPI) touchCachedAt(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[name]; ok {
+		e.cachedAt = time.Now()
+	}
+}
+
+// setInfo records info on name's entry, creating it if necessary, under the
+// lock.
+func (c *CachingAPI) setInfo(name string, info fs.FileInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[name]
+	if !ok {
+		e = &cacheEntry{}
+		c.entries[name] = e
+	}
+	e.info = info
+	e.cachedAt = time.Now()
+}
+
+// setChildren records children on name's entry, creating it if necessary,
+// under the lock.
+func (c *CachingAPI) setChildren(name string, children []fs.FileInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[name]
+	if !ok {
+		e = &cacheEntry{}
+		c.entries[name] = e
+	}
+	e.children = children
+	e.cachedAt = time.Now()
+}
+
+// touch records name as most-recently-used, bumping it to the front of the
+// LRU list and evicting from the back if that pushes curSize over MaxBytes.
+// It's called both when content is stored and on every cache hit that serves
+// already-cached content, so eviction order reflects actual access recency
+// rather than just fetch time.
+func (c *CachingAPI) touch(name string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.lruElem[name]; ok {
+		c.curSize -= el.Value.(*lruItem).size
+		c.lru.MoveToFront(el)
+		el.Value.(*lruItem).size = size
+	} else {
+		el := c.lru.PushFront(&lruItem{name: name, size: size})
+		c.lruElem[name] = el
+	}
+	c.curSize += size
+
+	for c.MaxBytes > 0 && c.curSize > c.MaxBytes && c.lru.Len() > 0 {
+		back := c.lru.Back()
+		item := back.Value.(*lruItem)
+		if item.name == name {
+			break
+		}
+		c.evictLocked(item.name)
+	}
+}
+
+type lruItem struct {
+	name string
+	size int64
+}
+
+// evictLocked drops the cached content for name. c.mu must be held.
+func (c *CachingAPI) evictLocked(name string) {
+	if el, ok := c.lruElem[name]; ok {
+		c.lru.Remove(el)
+		delete(c.lruElem, name)
+		c.curSize -= el.Value.(*lruItem).size
+	}
+	if e, ok := c.entries[name]; ok {
+		e.hasContent = false
+	}
+	c.Storage.Remove(name)
+}
+
+// invalidate drops every cached entry for name and, since name's own
+// metadata may now be stale too, its parent directory listing.
+func (c *CachingAPI) invalidate(name string) {
+	c.mu.Lock()
+	delete(c.entries, name)
+	if el, ok := c.lruElem[name]; ok {
+		c.lru.Remove(el)
+		delete(c.lruElem, name)
+		c.curSize -= el.Value.(*lruItem).size
+	}
+	delete(c.entries, parentOf(name))
+	c.mu.Unlock()
+
+	c.Storage.Remove(name)
+}
+
+// invalidateTree drops every cached entry for name and anything nested under
+// it, plus name's parent listing, along with their Storage blobs. RemoveAll
+// and Rename need this rather than plain invalidate: a directory removed or
+// renamed can have already-cached descendants (e.g. "/dir/a", "/dir/sub/b")
+// that invalidate's single-key drop would leave serving deleted or moved
+// content from cache until their TTL expires.
+func (c *CachingAPI) invalidateTree(name string) {
+	prefix := name + "/"
+
+	c.mu.Lock()
+	var dead []string
+	for key := range c.entries {
+		if key == name || strings.HasPrefix(key, prefix) {
+			dead = append(dead, key)
+		}
+	}
+	for _, key := range dead {
+		delete(c.entries, key)
+		if el, ok := c.lruElem[key]; ok {
+			c.lru.Remove(el)
+			delete(c.lruElem, key)
+			c.curSize -= el.Value.(*lruItem).size
+		}
+	}
+	delete(c.entries, parentOf(name))
+	c.mu.Unlock()
+
+	for _, key := range dead {
+		c.Storage.Remove(key)
+	}
+}
+
+func parentOf(name string) string {
+	dir := filepath.ToSlash(filepath.Dir(name))
+	if dir == "." {
+		return "/"
+	}
+	return dir
+}
+
+func (c *CachingAPI) GetContent(ctx context.Context, name string) (io.ReadCloser, error) {
+	e, ok := c.entry(name)
+	if ok && e.fresh(c.TTL) && e.hasContent {
+		if rc, err := c.Storage.Open(name); err == nil {
+			c.touch(name, e.size)
+			return rc, nil
+		}
+	}
+
+	if ok && e.hasContent {
+		if cond, upstreamOK := c.Upstream.(ConditionalAPI); upstreamOK && e.etag != "" {
+			rc, etag, notModified, err := cond.GetContentIfNoneMatch(ctx, name, e.etag)
+			if err != nil {
+				return nil, err
+			}
+			if notModified {
+				c.touchCachedAt(name)
+				c.touch(name, e.size)
+				return c.Storage.Open(name)
+			}
+			defer rc.Close()
+			return c.store(ctx, name, rc, etag)
+		}
+
+		// No ConditionalAPI/ETag available: fall back to a plain Stat as a
+		// HEAD-equivalent revalidation before paying for a full re-download.
+		if info, err := c.Upstream.Stat(ctx, name); err == nil && e.info != nil &&
+			info.ModTime().Equal(e.info.ModTime()) && info.Size() == e.info.Size() {
+			c.setInfo(name, info)
+			if rc, err := c.Storage.Open(name); err == nil {
+				c.touch(name, e.size)
+				return rc, nil
+			}
+		}
+	}
+
+	if cond, upstreamOK := c.Upstream.(ConditionalAPI); upstreamOK {
+		// An empty etag never matches, so this always returns content, along
+		// with the ETag to revalidate against once the entry expires.
+		rc, etag, _, err := cond.GetContentIfNoneMatch(ctx, name, "")
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return c.store(ctx, name, rc, etag)
+	}
+
+	rc, err := c.Upstream.GetContent(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return c.store(ctx, name, rc, "")
+}
+
+// store writes rc to the cache's Storage under name and returns a fresh
+// reader over the cached copy. It merges into any existing entry rather
+// than replacing it outright, fetching name's fs.FileInfo if not already
+// known, so a path populated only through GetContent still has an info
+// snapshot for the plain-Stat revalidation in GetContent to compare
+// against on the next TTL expiry.
+func (c *CachingAPI) store(ctx context.Context, name string, rc io.ReadCloser, etag string) (io.ReadCloser, error) {
+	if err := c.Storage.MkdirAll(".", 0o777); err != nil {
+		return nil, fmt.Errorf("cannot create cache dir: %w", err)
+	}
+
+	w, err := c.Storage.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cache entry: %w", err)
+	}
+	size, err := io.Copy(w, rc)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing, hadEntry := c.entry(name)
+	info := existing.info
+	if info == nil {
+		info, _ = c.Upstream.Stat(ctx, name)
+	}
+
+	c.mu.Lock()
+	e := &cacheEntry{etag: etag, cachedAt: time.Now(), hasContent: true, size: size, info: info}
+	if hadEntry {
+		e.children = existing.children
+	}
+	c.entries[name] = e
+	c.mu.Unlock()
+	c.touch(name, size)
+
+	return c.Storage.Open(name)
+}
+
+func (c *CachingAPI) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	if e, ok := c.entry(name); ok && e.fresh(c.TTL) && e.info != nil {
+		return e.info, nil
+	}
+
+	info, err := c.Upstream.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setInfo(name, info)
+	return info, nil
+}
+
+func (c *CachingAPI) GetChildren(ctx context.Context, name string) ([]fs.FileInfo, error) {
+	if e, ok := c.entry(name); ok && e.fresh(c.TTL) && e.children != nil {
+		return e.children, nil
+	}
+
+	children, err := c.Upstream.GetChildren(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setChildren(name, children)
+	return children, nil
+}
+
+func (c *CachingAPI) MkDir(ctx context.Context, name string, perm os.FileMode) error {
+	err := c.Upstream.MkDir(ctx, name, perm)
+	if err == nil {
+		c.invalidate(name)
+	}
+	return err
+}
+
+func (c *CachingAPI) Update(ctx context.Context, name string, rc io.Reader) error {
+	err := c.Upstream.Update(ctx, name, rc)
+	if err == nil {
+		c.invalidate(name)
+	}
+	return err
+}
+
+func (c *CachingAPI) NewFile(ctx context.Context, name string, rc io.Reader) error {
+	err := c.Upstream.NewFile(ctx, name, rc)
+	if err == nil {
+		c.invalidate(name)
+	}
+	return err
+}
+
+func (c *CachingAPI) RemoveAll(ctx context.Context, name string) error {
+	err := c.Upstream.RemoveAll(ctx, name)
+	if err == nil {
+		c.invalidateTree(name)
+	}
+	return err
+}
+
+func (c *CachingAPI) Rename(ctx context.Context, oldname string, newname string) error {
+	err := c.Upstream.Rename(ctx, oldname, newname)
+	if err == nil {
+		c.invalidateTree(oldname)
+		c.invalidateTree(newname)
+	}
+	return err
+}
+
+// guard to ensure that everything works
+var _ FileRESTAPI = &CachingAPI{}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// ErrPreconditionFailed is returned by a ConditionalAPI.UpdateIfMatch
+// implementation when the ETag supplied by the caller no longer matches the
+// current state of the resource -- i.e. the content changed since it was
+// last read, and the write would otherwise clobber a concurrent
+// modification.
+var ErrPreconditionFailed = errors.New("rest: precondition failed")
+
+// ConditionalAPI is an optional capability a FileRESTAPI implementation may
+// provide for ETag-based concurrency control and conditional-GET caching.
+// When a backend implements it, File records the ETag at open with
+// StatWithETag, fetches content with GetContentIfNoneMatch (falling back to
+// a plain GetContent when the content is unchanged since open), and uses
+// UpdateIfMatch to fail a write with ErrPreconditionFailed instead of
+// silently clobbering a concurrent modification.
+type ConditionalAPI interface {
+	// StatWithETag behaves like FileRESTAPI.Stat but additionally returns
+	// the current ETag of name.
+	StatWithETag(ctx context.Context, name string) (fs.FileInfo, string, error)
+
+	// GetContentIfNoneMatch returns the content of name, unless etag still
+	// matches the current ETag, in which case notModified is true and rc is
+	// nil. An empty etag never matches, so it always returns content.
+	GetContentIfNoneMatch(ctx context.Context, name, etag string) (rc io.ReadCloser, newETag string, notModified bool, err error)
+
+	// UpdateIfMatch replaces the content of name with rc, but only if etag
+	// still matches the current ETag of name. It returns ErrPreconditionFailed
+	// if it doesn't.
+	UpdateIfMatch(ctx context.Context, name string, rc io.Reader, etag string) error
+}
+
+// getContent fetches the current content of f, recording its ETag on f for
+// a later conditional write, if the backend implements ConditionalAPI.
+func (f *File) getContent(ctx context.Context) (io.ReadCloser, error) {
+	cond, ok := f.api.(ConditionalAPI)
+	if !ok {
+		return f.api.GetContent(ctx, f.name)
+	}
+
+	rc, etag, notModified, err := cond.GetContentIfNoneMatch(ctx, f.name, f.etag)
+	if err != nil {
+		return nil, err
+	}
+	if notModified {
+		// f.etag, recorded by OpenFileContext, still matches: the usual case
+		// for content unchanged since open. A File never has a local copy of
+		// its own content to fall back on -- fetch it unconditionally instead.
+		return f.api.GetContent(ctx, f.name)
+	}
+
+	f.etag = etag
+	return rc, nil
+}
+
+// updateContent writes rc back as the content of f, using UpdateIfMatch
+// instead of Update when the backend implements ConditionalAPI and f
+// already has a known ETag, so a concurrent modification made between open
+// and close surfaces as ErrPreconditionFailed instead of being clobbered.
+func (f *File) updateContent(ctx context.Context, rc io.Reader) error {
+	cond, ok := f.api.(ConditionalAPI)
+	if !ok || f.etag == "" {
+		return f.api.Update(ctx, f.name, rc)
+	}
+	return cond.UpdateIfMatch(ctx, f.name, rc, f.etag)
+}
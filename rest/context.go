@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"context"
+	"io/fs"
+	"os"
+)
+
+// WithContext returns a view of restfilesystem that satisfies fs.FS,
+// fs.ReadDirFS and fs.StatFS using ctx for every call into the underlying
+// FileRESTAPI, instead of context.Background(). This lets a caller such as
+// http.FileServerFS propagate a per-request context -- e.g. a middleware
+// that builds a fresh handle from the incoming *http.Request and serves with
+// it, so cancellation and request-scoped values reach the REST backend.
+func (restfilesystem *RESTFileSystem) WithContext(ctx context.Context) *ContextFileSystem {
+	return &ContextFileSystem{fs: restfilesystem, ctx: ctx}
+}
+
+// ContextFileSystem binds a RESTFileSystem to a fixed context.Context for
+// its fs.FS-style methods. Create one with RESTFileSystem.WithContext.
+type ContextFileSystem struct {
+	fs  *RESTFileSystem
+	ctx context.Context
+}
+
+// Open implements fs.FS.
+func (c *ContextFileSystem) Open(name string) (fs.File, error) {
+	return c.fs.OpenFileContext(c.ctx, name, os.O_RDONLY, 0)
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (c *ContextFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	return c.fs.readDir(c.ctx, name)
+}
+
+// Stat implements fs.StatFS.
+func (c *ContextFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return c.fs.API.Stat(c.ctx, name)
+}
+
+// guards to ensure that everything works
+var _ fs.FS = &ContextFileSystem{}
+var _ fs.ReadDirFS = &ContextFileSystem{}
+var _ fs.StatFS = &ContextFileSystem{}
@@ -0,0 +1,213 @@
+// Package http provides a reference rest.FileRESTAPI implementation that
+// talks to an upstream HTTP API, applying rest.RequestOptions found on the
+// call's context to every outbound request.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stefanbildl/restfs/rest"
+)
+
+// HTTPRestAPI is a rest.FileRESTAPI backed by a plain HTTP API: file content
+// lives under "{BaseURL}/content/{name}" and metadata under
+// "{BaseURL}/meta/{name}", addressed by a JSON-encoded fileInfo. It applies
+// rest.RequestOptions read off the request's context -- via
+// rest.RequestOptionsFromContext -- to every outbound *http.Request, so a
+// caller wrapped in rest.AuthMiddleware transparently forwards the incoming
+// Authorization header, cookie, or federated token upstream.
+type HTTPRestAPI struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+type fileInfo struct {
+	NameField    string      `json:"name"`
+	SizeField    int64       `json:"size"`
+	ModeField    fs.FileMode `json:"mode"`
+	ModTimeField time.Time   `json:"modTime"`
+	IsDirField   bool        `json:"isDir"`
+}
+
+func (fi *fileInfo) Name() string       { return fi.NameField }
+func (fi *fileInfo) Size() int64        { return fi.SizeField }
+func (fi *fileInfo) Mode() fs.FileMode  { return fi.ModeField }
+func (fi *fileInfo) ModTime() time.Time { return fi.ModTimeField }
+func (fi *fileInfo) IsDir() bool        { return fi.IsDirField }
+func (fi *fileInfo) Sys() any           { return nil }
+
+func (api *HTTPRestAPI) client() *http.Client {
+	if api.Client != nil {
+		return api.Client
+	}
+	return http.DefaultClient
+}
+
+// newRequest builds a *http.Request against api.BaseURL and applies the
+// rest.RequestOptions found on ctx, if any.
+func (api *HTTPRestAPI) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, api.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, ok := rest.RequestOptionsFromContext(ctx)
+	if !ok {
+		return req, nil
+	}
+
+	if opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.BearerToken)
+	}
+	for k, values := range opts.Headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if opts.TenantID != "" {
+		req.Header.Set("X-Tenant-ID", opts.TenantID)
+	}
+
+	return req, nil
+}
+
+func (api *HTTPRestAPI) do(req *http.Request) (*http.Response, error) {
+	resp, err := api.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fs.ErrNotExist
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s for %s %s", resp.Status, req.Method, req.URL)
+	}
+	return resp, nil
+}
+
+func (api *HTTPRestAPI) GetContent(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := api.newRequest(ctx, http.MethodGet, "/content/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := api.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (api *HTTPRestAPI) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	req, err := api.newRequest(ctx, http.MethodGet, "/meta/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := api.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var fi fileInfo
+	if err := json.NewDecoder(resp.Body).Decode(&fi); err != nil {
+		return nil, err
+	}
+	return &fi, nil
+}
+
+func (api *HTTPRestAPI) GetChildren(ctx context.Context, name string) ([]fs.FileInfo, error) {
+	req, err := api.newRequest(ctx, http.MethodGet, "/children/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := api.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var entries []fileInfo
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, len(entries))
+	for i := range entries {
+		infos[i] = &entries[i]
+	}
+	return infos, nil
+}
+
+func (api *HTTPRestAPI) MkDir(ctx context.Context, name string, perm fs.FileMode) error {
+	req, err := api.newRequest(ctx, http.MethodPut, "/meta/"+url.PathEscape(name), strings.NewReader(`{"isDir":true}`))
+	if err != nil {
+		return err
+	}
+	resp, err := api.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (api *HTTPRestAPI) Update(ctx context.Context, name string, rc io.Reader) error {
+	req, err := api.newRequest(ctx, http.MethodPut, "/content/"+url.PathEscape(name), rc)
+	if err != nil {
+		return err
+	}
+	resp, err := api.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (api *HTTPRestAPI) NewFile(ctx context.Context, name string, rc io.Reader) error {
+	req, err := api.newRequest(ctx, http.MethodPost, "/content/"+url.PathEscape(name), rc)
+	if err != nil {
+		return err
+	}
+	resp, err := api.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (api *HTTPRestAPI) RemoveAll(ctx context.Context, name string) error {
+	req, err := api.newRequest(ctx, http.MethodDelete, "/meta/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := api.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (api *HTTPRestAPI) Rename(ctx context.Context, oldname string, newname string) error {
+	body := fmt.Sprintf(`{"newName":%q}`, newname)
+	req, err := api.newRequest(ctx, http.MethodPost, "/rename/"+url.PathEscape(oldname), strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := api.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// guard to ensure that everything works
+var _ rest.FileRESTAPI = &HTTPRestAPI{}
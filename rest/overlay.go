@@ -0,0 +1,228 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// OverlayFS stacks an ordered list of FileRESTAPI layers and presents them
+// as a single FileRESTAPI, patterned after afero's CopyOnWriteFs and union
+// filesystems. Layers[0] is the top of the stack: reads consult layers
+// top-down and return the first hit, and directory listings merge entries
+// from every layer, a top layer's entry shadowing a lower one of the same
+// name. Writes are directed at Layers[Writable] (0 by default), copying a
+// file up from a lower layer on first write so the lower layer is never
+// mutated.
+//
+// OverlayFS does not forward the optional RangeGetter, ConditionalAPI or
+// ChunkedUploader capabilities of its layers, even when every layer
+// implements one: which layer a given read or write lands on depends on
+// layerOf/writableLayer at call time, so a single static type assertion
+// against *OverlayFS can't be answered correctly up front the way
+// NewBasePathAPI answers it for a single wrapped backend. Reads and
+// writes through an OverlayFS always go through the plain
+// GetContent/Update/NewFile path below.
+type OverlayFS struct {
+	Layers   []FileRESTAPI
+	Writable int
+
+	mu        sync.Mutex
+	whiteouts map[string]struct{}
+}
+
+// ErrNoWritableLayer is returned by a write operation when OverlayFS has no
+// layer configured at index Writable.
+var ErrNoWritableLayer = errors.New("rest: overlay has no writable layer")
+
+func (o *OverlayFS) writableLayer() (FileRESTAPI, error) {
+	if o.Writable < 0 || o.Writable >= len(o.Layers) {
+		return nil, ErrNoWritableLayer
+	}
+	return o.Layers[o.Writable], nil
+}
+
+func (o *OverlayFS) whitedOut(name string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.whiteouts[name]
+	return ok
+}
+
+func (o *OverlayFS) setWhiteout(name string, whited bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.whiteouts == nil {
+		o.whiteouts = make(map[string]struct{})
+	}
+	if whited {
+		o.whiteouts[name] = struct{}{}
+	} else {
+		delete(o.whiteouts, name)
+	}
+}
+
+// layerOf returns the topmost layer (and its index) in which name exists,
+// honoring whiteouts recorded by a prior RemoveAll/Rename.
+func (o *OverlayFS) layerOf(ctx context.Context, name string) (FileRESTAPI, int, error) {
+	if o.whitedOut(name) {
+		return nil, -1, fs.ErrNotExist
+	}
+	for i, layer := range o.Layers {
+		if _, err := layer.Stat(ctx, name); err == nil {
+			return layer, i, nil
+		}
+	}
+	return nil, -1, fs.ErrNotExist
+}
+
+func (o *OverlayFS) GetContent(ctx context.Context, name string) (io.ReadCloser, error) {
+	layer, _, err := o.layerOf(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return layer.GetContent(ctx, name)
+}
+
+func (o *OverlayFS) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	layer, _, err := o.layerOf(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return layer.Stat(ctx, name)
+}
+
+// GetChildren merges the listing of name across every layer, keeping the
+// topmost layer's entry for any name that appears in more than one.
+func (o *OverlayFS) GetChildren(ctx context.Context, name string) ([]fs.FileInfo, error) {
+	seen := make(map[string]bool)
+	var merged []fs.FileInfo
+	var lastErr error
+
+	for _, layer := range o.Layers {
+		children, err := layer.GetChildren(ctx, name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, c := range children {
+			if seen[c.Name()] || o.whitedOut(joinName(name, c.Name())) {
+				continue
+			}
+			seen[c.Name()] = true
+			merged = append(merged, c)
+		}
+	}
+
+	if len(merged) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return merged, nil
+}
+
+func joinName(dir, name string) string {
+	if dir == "" || dir == "/" {
+		return "/" + name
+	}
+	return dir + "/" + name
+}
+
+// copyUp copies name from the topmost layer it's found in onto the
+// writable layer, so a subsequent write on the writable layer sees it as
+// existing content rather than creating a divergent fresh file. It's a
+// no-op if name already exists on the writable layer or doesn't exist at
+// all.
+func (o *OverlayFS) copyUp(ctx context.Context, writable FileRESTAPI, name string) error {
+	if _, err := writable.Stat(ctx, name); err == nil {
+		return nil
+	}
+
+	layer, idx, err := o.layerOf(ctx, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if idx == o.Writable {
+		return nil
+	}
+
+	rc, err := layer.GetContent(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writable.NewFile(ctx, name, rc)
+}
+
+func (o *OverlayFS) MkDir(ctx context.Context, name string, perm os.FileMode) error {
+	writable, err := o.writableLayer()
+	if err != nil {
+		return err
+	}
+	o.setWhiteout(name, false)
+	return writable.MkDir(ctx, name, perm)
+}
+
+func (o *OverlayFS) Update(ctx context.Context, name string, rc io.Reader) error {
+	writable, err := o.writableLayer()
+	if err != nil {
+		return err
+	}
+	if err := o.copyUp(ctx, writable, name); err != nil {
+		return err
+	}
+	if _, err := writable.Stat(ctx, name); errors.Is(err, fs.ErrNotExist) {
+		return writable.NewFile(ctx, name, rc)
+	}
+	return writable.Update(ctx, name, rc)
+}
+
+func (o *OverlayFS) NewFile(ctx context.Context, name string, rc io.Reader) error {
+	writable, err := o.writableLayer()
+	if err != nil {
+		return err
+	}
+	o.setWhiteout(name, false)
+	return writable.NewFile(ctx, name, rc)
+}
+
+func (o *OverlayFS) RemoveAll(ctx context.Context, name string) error {
+	writable, err := o.writableLayer()
+	if err != nil {
+		return err
+	}
+
+	err = writable.RemoveAll(ctx, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// name may still be visible through a lower, read-only layer: record a
+	// whiteout so it no longer surfaces in reads or listings.
+	o.setWhiteout(name, true)
+	return nil
+}
+
+func (o *OverlayFS) Rename(ctx context.Context, oldname string, newname string) error {
+	writable, err := o.writableLayer()
+	if err != nil {
+		return err
+	}
+	if err := o.copyUp(ctx, writable, oldname); err != nil {
+		return err
+	}
+	if err := writable.Rename(ctx, oldname, newname); err != nil {
+		return err
+	}
+	o.setWhiteout(oldname, true)
+	o.setWhiteout(newname, false)
+	return nil
+}
+
+// guard to ensure that everything works
+var _ FileRESTAPI = &OverlayFS{}
@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// RangeGetter is an optional capability a FileRESTAPI implementation may
+// provide to serve partial-content reads without downloading the whole
+// object first. When length is negative, GetContentRange returns the
+// remainder of the file starting at off.
+type RangeGetter interface {
+	GetContentRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error)
+}
+
+// useRangeGetter reports whether f should stream reads through a
+// RangeGetter instead of buffering into a tempfile: the backend must
+// support it and the file must not be open for writing, since writes still
+// need a local buffer to read back from on Close.
+func (f *File) useRangeGetter() (RangeGetter, bool) {
+	if f.flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		return nil, false
+	}
+	rg, ok := f.api.(RangeGetter)
+	return rg, ok
+}
+
+// rangeReader returns the rolling reader positioned at f.pos, opening one
+// against the backend if none is open yet.
+func (f *File) rangeReader(ctx context.Context, rg RangeGetter) (io.ReadCloser, error) {
+	if f.rc != nil {
+		return f.rc, nil
+	}
+
+	rc, err := rg.GetContentRange(ctx, f.name, f.pos, -1)
+	if err != nil {
+		return nil, err
+	}
+	f.rc = rc
+	return rc, nil
+}
+
+// closeRangeReader closes and clears the rolling reader, if any. It's called
+// before reopening at a new offset, and on Close.
+func (f *File) closeRangeReader() error {
+	if f.rc == nil {
+		return nil
+	}
+	err := f.rc.Close()
+	f.rc = nil
+	return err
+}
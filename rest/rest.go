@@ -32,20 +32,41 @@ type FileRESTAPI interface {
 }
 
 type File struct {
-	mu    sync.Mutex
-	isNew bool
-	tf    *os.File
-	pos   int64
-	api   FileRESTAPI
-	name  string
-	flag  int
-	perm  os.FileMode
+	mu                   sync.Mutex
+	isNew                bool
+	tf                   *os.File
+	rc                   io.ReadCloser // rolling RangeGetter reader positioned at pos, see range.go
+	upload               UploadSession // in-progress ChunkedUploader session, see upload.go
+	uploadErr            error         // latest error from StartUpload or WriteChunk, if any
+	uploadBuf            []byte        // bytes buffered since uploadBufOff, not yet flushed, see upload.go
+	uploadBufOff         int64         // file offset uploadBuf[0] corresponds to
+	uploadMin, uploadMax int64         // chunk size hints from ChunkedUploader.ChunkSizeHints
+	pos                  int64
+	api                  FileRESTAPI
+	name                 string
+	flag                 int
+	perm                 os.FileMode
+	ctx                  context.Context
+	etag                 string // ETag of the last content known to be in sync, see conditional.go
+}
+
+// context returns the context the File was opened with, falling back to
+// context.Background() for Files constructed without one.
+func (f *File) context() context.Context {
+	if f.ctx != nil {
+		return f.ctx
+	}
+	return context.Background()
 }
 
 // ReadDir reads the named directory
 // and returns a list of directory entries sorted by filename.
 func (restfilesystem *RESTFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
-	fileInfos, err := restfilesystem.API.GetChildren(context.Background(), name)
+	return restfilesystem.readDir(context.Background(), name)
+}
+
+func (restfilesystem *RESTFileSystem) readDir(ctx context.Context, name string) ([]fs.DirEntry, error) {
+	fileInfos, err := restfilesystem.API.GetChildren(ctx, name)
 	if err != nil {
 		return nil, fmt.Errorf("cannot readdir: %w", err)
 	}
@@ -59,7 +80,7 @@ func (restfilesystem *RESTFileSystem) ReadDir(name string) ([]fs.DirEntry, error
 
 // Open implements fs.FS.
 func (restfilesystem *RESTFileSystem) Open(name string) (fs.File, error) {
-	return restfilesystem.OpenFile(context.Background(), name, os.O_RDONLY, 0)
+	return restfilesystem.OpenFileContext(context.Background(), name, os.O_RDONLY, 0)
 }
 
 // Create a new directory
@@ -67,14 +88,40 @@ func (restfilesystem *RESTFileSystem) Mkdir(ctx context.Context, name string, pe
 	return restfilesystem.API.MkDir(ctx, name, perm)
 }
 
+// OpenFile implements webdav.FileSystem. It delegates to OpenFileContext and
+// returns the result as a webdav.File.
 func (restfilesystem *RESTFileSystem) OpenFile(
 	ctx context.Context,
 	name string,
 	flag int,
 	perm os.FileMode,
 ) (webdav.File, error) {
+	return restfilesystem.OpenFileContext(ctx, name, flag, perm)
+}
 
-	_, err := restfilesystem.API.Stat(ctx, name)
+// OpenFileContext behaves like OpenFile but returns the concrete *File type,
+// giving callers access to the ReadContext/WriteContext/CloseContext methods
+// that aren't part of the webdav.File interface. The ctx is stored on the
+// returned File and used by Read, Write, Close and the other File methods
+// that call into the FileRESTAPI.
+func (restfilesystem *RESTFileSystem) OpenFileContext(
+	ctx context.Context,
+	name string,
+	flag int,
+	perm os.FileMode,
+) (*File, error) {
+	// Capture the current ETag at open time, if available, rather than only
+	// when tempFile later happens to call getContent: a truncating or
+	// write-only open never reads content back, so without this an O_TRUNC
+	// write would always fall back to an unconditional Update, silently
+	// bypassing the lost-update protection ConditionalAPI is meant to give.
+	var etag string
+	var err error
+	if cond, ok := restfilesystem.API.(ConditionalAPI); ok {
+		_, etag, err = cond.StatWithETag(ctx, name)
+	} else {
+		_, err = restfilesystem.API.Stat(ctx, name)
+	}
 	exists := !errors.Is(err, fs.ErrNotExist)
 
 	// some error occurs or it doesn't exist, yet and Create flag was not set
@@ -88,6 +135,8 @@ func (restfilesystem *RESTFileSystem) OpenFile(
 		perm:  perm,
 		name:  name,
 		api:   restfilesystem.API,
+		ctx:   ctx,
+		etag:  etag,
 	}, nil
 }
 
@@ -116,7 +165,7 @@ func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
 }
 
 func (f *File) stat() fs.FileInfo {
-	s, err := f.api.Stat(context.Background(), f.name)
+	s, err := f.api.Stat(f.context(), f.name)
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error in stat: %v\n", err)
@@ -145,6 +194,10 @@ func (f *File) Seek(offset int64, whence int) (int64, error) {
 		return ret, err
 	}
 
+	if err := f.closeRangeReader(); err != nil {
+		return 0, err
+	}
+
 	npos := f.pos
 
 	switch whence {
@@ -164,7 +217,7 @@ func (f *File) Seek(offset int64, whence int) (int64, error) {
 	return int64(f.pos), nil
 }
 
-func (f *File) tempFile() (*os.File, error) {
+func (f *File) tempFile(ctx context.Context) (*os.File, error) {
 	if f.tf != nil {
 		return f.tf, nil
 	}
@@ -181,7 +234,7 @@ func (f *File) tempFile() (*os.File, error) {
 
 	var size int64 = 0
 	if (f.flag&os.O_TRUNC) == 0 && !f.isNew {
-		rc, err := f.api.GetContent(context.Background(), f.name)
+		rc, err := f.getContent(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -201,11 +254,28 @@ func (f *File) tempFile() (*os.File, error) {
 	return f.tf, err
 }
 
+// Read implements io.Reader using the context the File was opened with.
 func (f *File) Read(p []byte) (n int, err error) {
+	return f.ReadContext(f.context(), p)
+}
+
+// ReadContext behaves like Read but uses ctx for the underlying FileRESTAPI
+// call instead of the context the File was opened with.
+func (f *File) ReadContext(ctx context.Context, p []byte) (n int, err error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	tf, err := f.tempFile()
+	if rg, ok := f.useRangeGetter(); ok {
+		rc, err := f.rangeReader(ctx, rg)
+		if err != nil {
+			return -1, err
+		}
+		n, err = rc.Read(p)
+		f.pos += int64(n)
+		return n, err
+	}
+
+	tf, err := f.tempFile(ctx)
 	if err != nil {
 		return -1, err
 	}
@@ -219,11 +289,22 @@ func (f *File) Read(p []byte) (n int, err error) {
 	return
 }
 
+// Write implements io.Writer using the context the File was opened with.
 func (f *File) Write(p []byte) (n int, err error) {
+	return f.WriteContext(f.context(), p)
+}
+
+// WriteContext behaves like Write but uses ctx for the underlying
+// FileRESTAPI call instead of the context the File was opened with.
+func (f *File) WriteContext(ctx context.Context, p []byte) (n int, err error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	tf, err := f.tempFile()
+	if cu, ok := f.useChunkedUploader(); ok {
+		return f.writeChunked(ctx, cu, p)
+	}
+
+	tf, err := f.tempFile(ctx)
 
 	if err != nil {
 		return -1, err
@@ -238,10 +319,34 @@ func (f *File) Write(p []byte) (n int, err error) {
 	return
 }
 
+// Close implements io.Closer using the context the File was opened with.
 func (file *File) Close() error {
+	return file.CloseContext(file.context())
+}
+
+// CloseContext behaves like Close but uses ctx for the NewFile/Update call
+// made against the FileRESTAPI instead of the context the File was opened
+// with.
+func (file *File) CloseContext(ctx context.Context) error {
 	file.mu.Lock()
 	defer file.mu.Unlock()
 
+	if err := file.closeRangeReader(); err != nil {
+		return err
+	}
+
+	if file.upload != nil {
+		if file.uploadErr == nil {
+			file.uploadErr = file.flushUpload(true)
+		}
+		if file.uploadErr != nil {
+			file.upload.Abort()
+			return file.uploadErr
+		}
+		_, err := file.upload.Commit()
+		return err
+	}
+
 	if file.tf != nil {
 		defer os.RemoveAll(file.tf.Name())
 		defer file.tf.Close()
@@ -259,7 +364,7 @@ func (file *File) Close() error {
 		}
 
 		defer rc.Close()
-		err = file.api.NewFile(context.Background(), file.name, rc)
+		err = file.api.NewFile(ctx, file.name, rc)
 		if err != nil {
 			return err
 		}
@@ -284,9 +389,11 @@ func (file *File) Close() error {
 		defer readFile.Close()
 
 		if file.isNew {
-			file.api.NewFile(context.Background(), file.name, readFile)
+			file.api.NewFile(ctx, file.name, readFile)
 		} else {
-			file.api.Update(context.Background(), file.name, readFile)
+			if err := file.updateContent(ctx, readFile); err != nil {
+				return err
+			}
 		}
 
 		readFile.Close()
@@ -296,7 +403,7 @@ func (file *File) Close() error {
 }
 
 func (file *File) Readdir(count int) ([]fs.FileInfo, error) {
-	return file.api.GetChildren(context.Background(), file.name)
+	return file.api.GetChildren(file.context(), file.name)
 }
 
 func (f *File) Stat() (fs.FileInfo, error) {
@@ -308,7 +415,7 @@ func (f *File) Stat() (fs.FileInfo, error) {
 		}, nil
 	}
 
-	return f.api.Stat(context.Background(), f.name)
+	return f.api.Stat(f.context(), f.name)
 }
 
 type newFileInfo struct {
@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"context"
+	"io/fs"
+	"os"
+)
+
+// UploadSession represents an in-progress chunked upload started by a
+// ChunkedUploader. WriteChunk may be called multiple times, in any order,
+// with off set by the caller's Seek position -- a backend that only
+// supports sequential appends should reject out-of-order offsets itself.
+type UploadSession interface {
+	// WriteChunk uploads p as the bytes of the file starting at off.
+	WriteChunk(off int64, p []byte) error
+
+	// Abort discards the session; no chunk written through it becomes
+	// visible.
+	Abort() error
+
+	// Commit finalizes the upload -- e.g. completing an S3-style multipart
+	// upload or a tus.io resumable one -- and returns the resulting file's
+	// metadata.
+	Commit() (fs.FileInfo, error)
+}
+
+// ChunkedUploader is an optional capability a FileRESTAPI implementation
+// may provide so large writes don't have to be buffered to a local
+// tempfile first. When a backend implements it, File.Write routes directly
+// to the UploadSession instead of a tempfile, for writes that don't need
+// to preserve existing content first (new files, and truncating opens).
+type ChunkedUploader interface {
+	// StartUpload begins a new chunked upload of name.
+	StartUpload(ctx context.Context, name string) (UploadSession, error)
+
+	// ChunkSizeHints returns the minimum and maximum chunk size, in bytes,
+	// the backend will accept from WriteChunk. max <= 0 means no upper
+	// bound; min <= 0 means no minimum (other than the final chunk, which
+	// may always be short).
+	ChunkSizeHints() (min, max int64)
+}
+
+// useChunkedUploader reports whether f should route writes directly to a
+// ChunkedUploader session instead of buffering them in a tempfile. This
+// only applies when f doesn't need to seed itself with existing remote
+// content first -- a new file, or a truncating open -- since a session has
+// no way to read back the bytes it hasn't been given yet.
+//
+// It also declines when f holds an ETag from a ConditionalAPI-backed open:
+// UploadSession.Commit has no way to carry an If-Match, so routing such a
+// write through it would silently drop the lost-update protection
+// ConditionalAPI provides. The tempfile path handles that case instead, via updateContent's
+// UpdateIfMatch.
+func (f *File) useChunkedUploader() (ChunkedUploader, bool) {
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return nil, false
+	}
+	if !f.isNew && f.flag&os.O_TRUNC == 0 {
+		return nil, false
+	}
+	cu, ok := f.api.(ChunkedUploader)
+	if !ok {
+		return nil, false
+	}
+	if _, condOK := f.api.(ConditionalAPI); condOK && f.etag != "" {
+		return nil, false
+	}
+	return cu, true
+}
+
+// startUpload lazily starts f's UploadSession and records its chunk size
+// hints.
+func (f *File) startUpload(ctx context.Context, cu ChunkedUploader) (UploadSession, error) {
+	if f.upload != nil {
+		return f.upload, nil
+	}
+	session, err := cu.StartUpload(ctx, f.name)
+	if err != nil {
+		return nil, err
+	}
+	f.upload = session
+	f.uploadMin, f.uploadMax = cu.ChunkSizeHints()
+	return session, nil
+}
+
+// defaultChunkFlushSize bounds how much writeChunked buffers before flushing
+// when the backend's ChunkSizeHints leaves max unset (<= 0, "no upper
+// bound"). Without a cap there, a large write would accumulate entirely in
+// uploadBuf until Close -- trading the tempfile-on-disk buffering
+// ChunkedUploader exists to avoid for the same amount of buffering in RAM.
+const defaultChunkFlushSize = 8 << 20 // 8 MiB
+
+// writeChunked buffers p and flushes whole chunks through the UploadSession
+// as they reach the flush threshold, so a backend advertising a large
+// minimum chunk size -- the S3 multipart use case ChunkedUploader is meant
+// for -- never sees a part smaller than it asked for, short of the final
+// chunk flushed by CloseContext. A Write at an offset not contiguous with
+// the buffered bytes (after a Seek) also flushes the buffer as a short
+// chunk, whatever its size. The threshold is uploadMax when the backend
+// set one, else whichever is larger of uploadMin and defaultChunkFlushSize.
+func (f *File) writeChunked(ctx context.Context, cu ChunkedUploader, p []byte) (int, error) {
+	session, err := f.startUpload(ctx, cu)
+	if err != nil {
+		f.uploadErr = err
+		return -1, err
+	}
+
+	if len(f.uploadBuf) == 0 {
+		f.uploadBufOff = f.pos
+	} else if f.uploadBufOff+int64(len(f.uploadBuf)) != f.pos {
+		// A Seek landed us somewhere non-contiguous with what's buffered;
+		// flush it as a short chunk before starting a fresh one at f.pos.
+		if err := f.flushUpload(true); err != nil {
+			f.uploadErr = err
+			return -1, err
+		}
+		f.uploadBufOff = f.pos
+	}
+
+	f.uploadBuf = append(f.uploadBuf, p...)
+
+	flushAt := f.uploadMax
+	if flushAt <= 0 {
+		flushAt = defaultChunkFlushSize
+		if f.uploadMin > flushAt {
+			flushAt = f.uploadMin
+		}
+	}
+
+	for int64(len(f.uploadBuf)) >= flushAt {
+		chunk := f.uploadBuf[:flushAt]
+		if err := session.WriteChunk(f.uploadBufOff, chunk); err != nil {
+			f.uploadErr = err
+			return -1, err
+		}
+		f.uploadBufOff += flushAt
+		f.uploadBuf = append([]byte(nil), f.uploadBuf[flushAt:]...)
+	}
+
+	f.pos += int64(len(p))
+	return len(p), nil
+}
+
+// flushUpload writes any buffered bytes through WriteChunk. If final is
+// false, a buffer below uploadMin is left in place for a later WriteContext
+// call to top up -- only CloseContext's final flush may send a short
+// chunk, since the backend's minimum doesn't apply to the last one.
+func (f *File) flushUpload(final bool) error {
+	if len(f.uploadBuf) == 0 {
+		return nil
+	}
+	if !final && f.uploadMin > 0 && int64(len(f.uploadBuf)) < f.uploadMin {
+		return nil
+	}
+
+	err := f.upload.WriteChunk(f.uploadBufOff, f.uploadBuf)
+	f.uploadBufOff += int64(len(f.uploadBuf))
+	f.uploadBuf = nil
+	return err
+}